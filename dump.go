@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/morgangallant/trading/store"
+	_ "github.com/morgangallant/trading/store/leveldb"
+	_ "github.com/morgangallant/trading/store/memory"
+	_ "github.com/morgangallant/trading/store/sql"
+	"github.com/morgangallant/trading/tickstore"
+)
+
+// runDump implements the "dump" subcommand: `trading dump -symbol AAPL`
+// writes a symbol's tick history to CSV for offline research.
+func runDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	dsn := fs.String("store", "leveldb:///tmp/tradingstore", "Store DSN to read ticks from")
+	symbol := fs.String("symbol", "", "Symbol to dump (required)")
+	from := fs.String("from", "", "RFC3339 start time (default: the beginning of time)")
+	to := fs.String("to", "", "RFC3339 end time (default: now)")
+	out := fs.String("out", "", "Output CSV path (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" {
+		return fmt.Errorf("-symbol is required")
+	}
+
+	fromTime := time.Unix(0, 0).UTC()
+	if *from != "" {
+		t, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		fromTime = t
+	}
+	toTime := time.Now().UTC()
+	if *to != "" {
+		t, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		toTime = t
+	}
+
+	s, err := store.Open(*dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	ts := tickstore.New(s)
+	defer ts.Close()
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	it, err := ts.Range(*symbol, fromTime, toTime)
+	if err != nil {
+		return fmt.Errorf("failed to range over %s: %w", *symbol, err)
+	}
+
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+	if err := cw.Write([]string{"time", "price"}); err != nil {
+		return err
+	}
+	for it.Next() {
+		tick := it.Tick()
+		row := []string{
+			tick.Time.Format(time.RFC3339Nano),
+			strconv.FormatFloat(tick.Price, 'f', -1, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+// Package leveldb provides the "leveldb" store.Store driver, backed by
+// syndtr/goleveldb. DSNs look like "leveldb:///path/to/dir".
+package leveldb
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/morgangallant/trading/store"
+)
+
+func init() {
+	store.Register("leveldb", Open)
+}
+
+// updateLock serializes Update transactions against one another.
+//
+// An earlier version of this lock tried to stripe on key so unrelated
+// Updates wouldn't contend, acquiring each key's stripe lazily as fn
+// touched it. Because fn discovers its keys at runtime (it can read one
+// key and decide what to touch next from the result), the only way to
+// keep two transactions from acquiring the same two stripes in opposite
+// orders was to release every stripe held so far and reacquire the full
+// set in sorted order whenever a new, lower-sorting stripe was needed. But
+// that release briefly let a concurrent Update in: it could read, modify,
+// and commit against the just-released stripe, after which the first
+// transaction resumed and applied its batch from a now-stale read — a
+// lost update, defeating the isolation Update promises every caller. A
+// single mutex has no such window: once Update holds it, no other Update
+// can observe or modify the store until it's released. Plain Get, Put,
+// Delete, CommitBatch and Snapshot calls do not take this lock.
+type updateLock struct {
+	mu sync.Mutex
+}
+
+type pstore struct {
+	ldb     *leveldb.DB
+	updates updateLock
+}
+
+func (ps *pstore) Get(key string) ([]byte, error) {
+	val, err := ps.ldb.Get([]byte(key), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, store.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("leveldb error: %w", err)
+	}
+	if val == nil {
+		return nil, store.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+func (ps *pstore) Put(key string, value []byte) error {
+	if err := store.CheckSizes(key, value); err != nil {
+		return err
+	}
+	if err := ps.ldb.Put([]byte(key), value, nil); err != nil {
+		return fmt.Errorf("leveldb error: %w", err)
+	}
+	return nil
+}
+
+func (ps *pstore) Delete(key string) error {
+	if err := ps.ldb.Delete([]byte(key), nil); err != nil {
+		return fmt.Errorf("leveldb error: %w", err)
+	}
+	return nil
+}
+
+type pbatch struct {
+	errmu sync.Mutex
+	err   error
+	batch *leveldb.Batch
+}
+
+func (pb *pbatch) Put(key string, value []byte) {
+	pb.errmu.Lock()
+	defer pb.errmu.Unlock()
+	if pb.err != nil {
+		return
+	}
+	if err := store.CheckSizes(key, value); err != nil {
+		pb.err = err
+		return
+	}
+	pb.batch.Put([]byte(key), value)
+}
+
+func (pb *pbatch) Delete(key string) {
+	pb.errmu.Lock()
+	defer pb.errmu.Unlock()
+	if pb.err != nil {
+		return
+	}
+	pb.batch.Delete([]byte(key))
+}
+
+// batchReplayAdapter bridges our store.BatchReplay (whose Put/Delete return
+// an error) to goleveldb's leveldb.BatchReplay (whose Put/Delete don't),
+// latching the first error so it can be surfaced once Batch.Replay returns.
+type batchReplayAdapter struct {
+	r   store.BatchReplay
+	err error
+}
+
+func (a *batchReplayAdapter) Put(key, value []byte) {
+	if a.err != nil {
+		return
+	}
+	a.err = a.r.Put(string(key), value)
+}
+
+func (a *batchReplayAdapter) Delete(key []byte) {
+	if a.err != nil {
+		return
+	}
+	a.err = a.r.Delete(string(key))
+}
+
+func (pb *pbatch) Replay(r store.BatchReplay) error {
+	pb.errmu.Lock()
+	defer pb.errmu.Unlock()
+	if pb.err != nil {
+		return pb.err
+	}
+	adapter := &batchReplayAdapter{r: r}
+	if err := pb.batch.Replay(adapter); err != nil {
+		return fmt.Errorf("leveldb error: %w", err)
+	}
+	return adapter.err
+}
+
+func (ps *pstore) BeginBatch() store.BatchMutation {
+	return &pbatch{batch: new(leveldb.Batch)}
+}
+
+func (ps *pstore) CommitBatch(batch store.BatchMutation) error {
+	b, ok := batch.(*pbatch)
+	if !ok {
+		return fmt.Errorf("invalid batch mutation type: %T", batch)
+	}
+	b.errmu.Lock()
+	defer b.errmu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	if err := ps.ldb.Write(b.batch, nil); err != nil {
+		return fmt.Errorf("leveldb error: %w", err)
+	}
+	return nil
+}
+
+type piter struct {
+	it     iterator.Iterator
+	closed bool
+}
+
+func (pi *piter) Next() bool {
+	if pi.closed {
+		panic("next() called on closed iterator")
+	}
+	return pi.it.Next()
+}
+
+func (pi *piter) Key() string {
+	return string(pi.it.Key())
+}
+
+func (pi *piter) Value() []byte {
+	return pi.it.Value()
+}
+
+func (pi *piter) Close() error {
+	pi.closed = true
+	pi.it.Release()
+	if err := pi.it.Error(); err != nil {
+		return fmt.Errorf("leveldb error: %w", err)
+	}
+	return nil
+}
+
+func (ps *pstore) RangeScan(start, end string) store.Iterator {
+	var sb, eb []byte
+	if start != "" {
+		sb = []byte(start)
+	}
+	if end != "" {
+		eb = []byte(end)
+	}
+	return &piter{
+		it: ps.ldb.NewIterator(&util.Range{
+			Start: sb,
+			Limit: eb,
+		}, nil),
+		closed: false,
+	}
+}
+
+func (ps *pstore) PrefixScan(prefix string) store.Iterator {
+	return &piter{
+		it:     ps.ldb.NewIterator(util.BytesPrefix([]byte(prefix)), nil),
+		closed: false,
+	}
+}
+
+// psnapshot is a consistent read-only view backed by leveldb's own
+// snapshot mechanism.
+type psnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (pn *psnapshot) Get(key string) ([]byte, error) {
+	val, err := pn.snap.Get([]byte(key), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, store.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("leveldb error: %w", err)
+	}
+	return val, nil
+}
+
+func (pn *psnapshot) RangeScan(start, end string) store.Iterator {
+	var sb, eb []byte
+	if start != "" {
+		sb = []byte(start)
+	}
+	if end != "" {
+		eb = []byte(end)
+	}
+	return &piter{it: pn.snap.NewIterator(&util.Range{Start: sb, Limit: eb}, nil)}
+}
+
+func (pn *psnapshot) PrefixScan(prefix string) store.Iterator {
+	return &piter{it: pn.snap.NewIterator(util.BytesPrefix([]byte(prefix)), nil)}
+}
+
+func (pn *psnapshot) Close() error {
+	pn.snap.Release()
+	return nil
+}
+
+func (ps *pstore) Snapshot() (store.Snapshot, error) {
+	snap, err := ps.ldb.GetSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("leveldb error: %w", err)
+	}
+	return &psnapshot{snap: snap}, nil
+}
+
+// ptxn is the Txn handed to the function passed to Update. Reads fall
+// through to any value already written earlier in the same transaction,
+// then to the underlying store.
+type ptxn struct {
+	ps      *pstore
+	batch   *leveldb.Batch
+	written map[string][]byte // nil value means the key was deleted
+}
+
+func (t *ptxn) Get(key string) ([]byte, error) {
+	if val, ok := t.written[key]; ok {
+		if val == nil {
+			return nil, store.ErrKeyNotFound
+		}
+		return val, nil
+	}
+	return t.ps.Get(key)
+}
+
+func (t *ptxn) Put(key string, value []byte) error {
+	if err := store.CheckSizes(key, value); err != nil {
+		return err
+	}
+	t.batch.Put([]byte(key), value)
+	t.written[key] = value
+	return nil
+}
+
+func (t *ptxn) Delete(key string) error {
+	t.batch.Delete([]byte(key))
+	t.written[key] = nil
+	return nil
+}
+
+// Update runs fn under the store's single update lock and applies fn's
+// writes in a single leveldb batch once fn returns nil. It may be called
+// more than once if a caller wraps it in a retry loop, so fn must be safe
+// to re-run.
+func (ps *pstore) Update(fn func(store.Txn) error) error {
+	ps.updates.mu.Lock()
+	defer ps.updates.mu.Unlock()
+
+	t := &ptxn{
+		ps:      ps,
+		batch:   new(leveldb.Batch),
+		written: make(map[string][]byte),
+	}
+	if err := fn(t); err != nil {
+		return err
+	}
+	if err := ps.ldb.Write(t.batch, nil); err != nil {
+		return fmt.Errorf("leveldb error: %w", err)
+	}
+	return nil
+}
+
+// Open implements store.Opener for the "leveldb" scheme. The DSN's path
+// component is used as the on-disk directory, e.g.
+// "leveldb:///tmp/tradingstore" opens "/tmp/tradingstore".
+func Open(dsn string) (store.Store, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %w", err)
+	}
+	path := u.Path
+	if path == "" {
+		path = u.Opaque
+	}
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		BlockCacheCapacity: 512 * opt.MiB,             // 512 MiB LRU Cache
+		Filter:             filter.NewBloomFilter(10), // 10-bit Bloom Filter
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open leveldb: %w", err)
+	}
+	return &pstore{ldb: db}, nil
+}
@@ -0,0 +1,358 @@
+// Package memory provides the "memory" store.Store driver: an in-memory,
+// non-durable backend intended for unit tests and local experimentation.
+// DSNs look like "memory://" or "memory://anything" — the rest of the DSN
+// is ignored since each Open call produces an independent, empty store.
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/morgangallant/trading/store"
+)
+
+func init() {
+	store.Register("memory", Open)
+}
+
+// mstore keeps keys in sorted order so RangeScan/PrefixScan match the
+// byte-lexicographic ordering every other driver produces.
+type mstore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+	keys []string // sorted
+}
+
+func (ms *mstore) Get(key string) ([]byte, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	val, ok := ms.data[key]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+// insert assumes ms.mu is held for writing.
+func (ms *mstore) insert(key string, value []byte) {
+	if _, exists := ms.data[key]; !exists {
+		i := sort.SearchStrings(ms.keys, key)
+		ms.keys = append(ms.keys, "")
+		copy(ms.keys[i+1:], ms.keys[i:])
+		ms.keys[i] = key
+	}
+	ms.data[key] = value
+}
+
+// remove assumes ms.mu is held for writing.
+func (ms *mstore) remove(key string) {
+	if _, exists := ms.data[key]; !exists {
+		return
+	}
+	delete(ms.data, key)
+	i := sort.SearchStrings(ms.keys, key)
+	ms.keys = append(ms.keys[:i], ms.keys[i+1:]...)
+}
+
+func (ms *mstore) Put(key string, value []byte) error {
+	if err := store.CheckSizes(key, value); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.insert(key, value)
+	return nil
+}
+
+func (ms *mstore) Delete(key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.remove(key)
+	return nil
+}
+
+type mmutation struct {
+	puts    map[string][]byte
+	deletes map[string]struct{}
+	order   []string // keys in the order operations were applied
+	err     error    // first size-cap violation, returned by Replay and CommitBatch
+}
+
+func (mm *mmutation) Put(key string, value []byte) {
+	if err := store.CheckSizes(key, value); err != nil {
+		if mm.err == nil {
+			mm.err = err
+		}
+		return
+	}
+	delete(mm.deletes, key)
+	if _, exists := mm.puts[key]; !exists {
+		mm.order = append(mm.order, key)
+	}
+	mm.puts[key] = value
+}
+
+func (mm *mmutation) Delete(key string) {
+	delete(mm.puts, key)
+	if _, exists := mm.deletes[key]; !exists {
+		mm.order = append(mm.order, key)
+	}
+	mm.deletes[key] = struct{}{}
+}
+
+func (mm *mmutation) Replay(r store.BatchReplay) error {
+	if mm.err != nil {
+		return mm.err
+	}
+	for _, key := range mm.order {
+		var err error
+		if val, ok := mm.puts[key]; ok {
+			err = r.Put(key, val)
+		} else {
+			err = r.Delete(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *mstore) BeginBatch() store.BatchMutation {
+	return &mmutation{
+		puts:    make(map[string][]byte),
+		deletes: make(map[string]struct{}),
+	}
+}
+
+func (ms *mstore) CommitBatch(batch store.BatchMutation) error {
+	mm, ok := batch.(*mmutation)
+	if !ok {
+		return &badBatchError{batch}
+	}
+	if mm.err != nil {
+		return mm.err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, key := range mm.order {
+		if val, ok := mm.puts[key]; ok {
+			ms.insert(key, val)
+			continue
+		}
+		ms.remove(key)
+	}
+	return nil
+}
+
+type badBatchError struct {
+	batch store.BatchMutation
+}
+
+func (e *badBatchError) Error() string {
+	return "memory: batch mutation was not created by this store"
+}
+
+// miter walks a pre-computed, sorted snapshot of keys: keys added or
+// removed after the iterator is created do not change the set it walks.
+// Value reads the live store, so it reflects later writes to those keys
+// and returns nil for a key deleted since.
+type miter struct {
+	ms   *mstore
+	keys []string
+	pos  int
+}
+
+func (mi *miter) Next() bool {
+	mi.pos++
+	return mi.pos < len(mi.keys)
+}
+
+func (mi *miter) Key() string {
+	return mi.keys[mi.pos]
+}
+
+func (mi *miter) Value() []byte {
+	mi.ms.mu.RLock()
+	defer mi.ms.mu.RUnlock()
+	return mi.ms.data[mi.keys[mi.pos]]
+}
+
+func (mi *miter) Close() error {
+	return nil
+}
+
+func (ms *mstore) RangeScan(start, end string) store.Iterator {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	lo := 0
+	if start != "" {
+		lo = sort.SearchStrings(ms.keys, start)
+	}
+	hi := len(ms.keys)
+	if end != "" {
+		hi = sort.SearchStrings(ms.keys, end)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	snapshot := make([]string, hi-lo)
+	copy(snapshot, ms.keys[lo:hi])
+	return &miter{ms: ms, keys: snapshot, pos: -1}
+}
+
+func (ms *mstore) PrefixScan(prefix string) store.Iterator {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	lo := sort.SearchStrings(ms.keys, prefix)
+	hi := lo
+	for hi < len(ms.keys) && hasPrefix(ms.keys[hi], prefix) {
+		hi++
+	}
+	snapshot := make([]string, hi-lo)
+	copy(snapshot, ms.keys[lo:hi])
+	return &miter{ms: ms, keys: snapshot, pos: -1}
+}
+
+func hasPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) && s[:len(prefix)] == prefix
+}
+
+// msnapshot is a point-in-time copy of the store's keys and values, so it
+// stays consistent even as the live store is mutated afterward.
+type msnapshot struct {
+	data map[string][]byte
+	keys []string // sorted
+}
+
+func (mn *msnapshot) Get(key string) ([]byte, error) {
+	val, ok := mn.data[key]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+func (mn *msnapshot) snapIter(lo, hi int) store.Iterator {
+	keys := make([]string, hi-lo)
+	copy(keys, mn.keys[lo:hi])
+	return &msnapIter{snap: mn, keys: keys, pos: -1}
+}
+
+func (mn *msnapshot) RangeScan(start, end string) store.Iterator {
+	lo := 0
+	if start != "" {
+		lo = sort.SearchStrings(mn.keys, start)
+	}
+	hi := len(mn.keys)
+	if end != "" {
+		hi = sort.SearchStrings(mn.keys, end)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return mn.snapIter(lo, hi)
+}
+
+func (mn *msnapshot) PrefixScan(prefix string) store.Iterator {
+	lo := sort.SearchStrings(mn.keys, prefix)
+	hi := lo
+	for hi < len(mn.keys) && hasPrefix(mn.keys[hi], prefix) {
+		hi++
+	}
+	return mn.snapIter(lo, hi)
+}
+
+func (mn *msnapshot) Close() error {
+	return nil
+}
+
+type msnapIter struct {
+	snap *msnapshot
+	keys []string
+	pos  int
+}
+
+func (mi *msnapIter) Next() bool {
+	mi.pos++
+	return mi.pos < len(mi.keys)
+}
+
+func (mi *msnapIter) Key() string   { return mi.keys[mi.pos] }
+func (mi *msnapIter) Value() []byte { return mi.snap.data[mi.keys[mi.pos]] }
+func (mi *msnapIter) Close() error  { return nil }
+
+// Snapshot copies the store's current keys and values so the result stays
+// consistent regardless of later writes.
+func (ms *mstore) Snapshot() (store.Snapshot, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	data := make(map[string][]byte, len(ms.data))
+	for k, v := range ms.data {
+		data[k] = v
+	}
+	keys := make([]string, len(ms.keys))
+	copy(keys, ms.keys)
+	return &msnapshot{data: data, keys: keys}, nil
+}
+
+// mtxn is the Txn handed to the function passed to Update. Reads fall
+// through to any value already written earlier in the same transaction,
+// then to the underlying store.
+type mtxn struct {
+	ms      *mstore
+	written map[string][]byte // nil value means the key was deleted
+}
+
+func (t *mtxn) Get(key string) ([]byte, error) {
+	if val, ok := t.written[key]; ok {
+		if val == nil {
+			return nil, store.ErrKeyNotFound
+		}
+		return val, nil
+	}
+	val, ok := t.ms.data[key]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+func (t *mtxn) Put(key string, value []byte) error {
+	if err := store.CheckSizes(key, value); err != nil {
+		return err
+	}
+	t.written[key] = value
+	return nil
+}
+
+func (t *mtxn) Delete(key string) error {
+	t.written[key] = nil
+	return nil
+}
+
+// Update holds the store's lock for the duration of fn, so concurrent
+// Update calls are fully serialized. It may be called more than once if a
+// caller wraps it in a retry loop, so fn must be safe to re-run.
+func (ms *mstore) Update(fn func(store.Txn) error) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	t := &mtxn{ms: ms, written: make(map[string][]byte)}
+	if err := fn(t); err != nil {
+		return err
+	}
+	for key, val := range t.written {
+		if val == nil {
+			ms.remove(key)
+			continue
+		}
+		ms.insert(key, val)
+	}
+	return nil
+}
+
+// Open implements store.Opener for the "memory" scheme. Every call returns
+// a fresh, independent store.
+func Open(dsn string) (store.Store, error) {
+	return &mstore{data: make(map[string][]byte)}, nil
+}
@@ -0,0 +1,480 @@
+// Package sql provides "sqlite" and "postgres" store.Store drivers on top
+// of database/sql. Both map Get/Put/Delete/RangeScan/PrefixScan/
+// BatchMutation onto a single `kv(key BLOB PRIMARY KEY, value BLOB)` table,
+// so the two drivers can be exercised by the same test suite as every
+// other backend.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/morgangallant/trading/store"
+)
+
+func init() {
+	store.Register("sqlite", openSQLite)
+	store.Register("postgres", openPostgres)
+}
+
+const createTableSQLite = `CREATE TABLE IF NOT EXISTS kv (
+	key BLOB PRIMARY KEY,
+	value BLOB NOT NULL
+)`
+
+const createTablePostgres = `CREATE TABLE IF NOT EXISTS kv (
+	key BYTEA PRIMARY KEY,
+	value BYTEA NOT NULL
+)`
+
+// placeholder renders the i-th (1-indexed) bind parameter for a dialect.
+type placeholder func(i int) string
+
+func questionMark(i int) string { return "?" }
+func dollarN(i int) string      { return fmt.Sprintf("$%d", i) }
+
+// openSQLite implements store.Opener for the "sqlite" scheme. The DSN's
+// path component is used as the database file, e.g. "sqlite:///tmp/a.db".
+func openSQLite(dsn string) (store.Store, error) {
+	path := strings.TrimPrefix(dsn, "sqlite://")
+	sqliteDSN := path
+	if path == ":memory:" {
+		// A bare ":memory:" DSN gives every pooled connection its own
+		// private, empty database, so a query can land on a connection
+		// that's never seen the kv table or any prior write. sqlite's
+		// shared-cache mode makes every connection opened against it see
+		// the same in-memory database instead, the way every other
+		// dialect naturally does by sharing a file or a server.
+		sqliteDSN = "file::memory:?cache=shared"
+	}
+	db, err := sql.Open("sqlite3", sqliteDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	if _, err := db.Exec(createTableSQLite); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create kv table: %w", err)
+	}
+	ss, err := newSQLStore(db, questionMark, true)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+	return ss, nil
+}
+
+// openPostgres implements store.Opener for the "postgres" scheme. The full
+// DSN is passed through to lib/pq unchanged.
+func openPostgres(dsn string) (store.Store, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	if _, err := db.Exec(createTablePostgres); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create kv table: %w", err)
+	}
+	ss, err := newSQLStore(db, dollarN, false)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+	return ss, nil
+}
+
+type sqlStore struct {
+	db *sql.DB
+	ph placeholder
+
+	// sqlite is set for the "sqlite" dialect, whose database/sql driver
+	// (mattn/go-sqlite3) rejects BeginTx with any non-default isolation
+	// level or ReadOnly: true. sqlite already gives every transaction a
+	// consistent snapshot of the database from BEGIN, so Snapshot and
+	// Update fall back to the default TxOptions on this dialect instead.
+	sqlite bool
+
+	getStmt    *sql.Stmt
+	upsertStmt *sql.Stmt
+	deleteStmt *sql.Stmt
+}
+
+func newSQLStore(db *sql.DB, ph placeholder, sqlite bool) (*sqlStore, error) {
+	ss := &sqlStore{db: db, ph: ph, sqlite: sqlite}
+	var err error
+	if ss.getStmt, err = db.Prepare(fmt.Sprintf("SELECT value FROM kv WHERE key = %s", ph(1))); err != nil {
+		return nil, fmt.Errorf("failed to prepare get statement: %w", err)
+	}
+	if ss.upsertStmt, err = db.Prepare(upsertSQL(ph)); err != nil {
+		return nil, fmt.Errorf("failed to prepare upsert statement: %w", err)
+	}
+	if ss.deleteStmt, err = db.Prepare(fmt.Sprintf("DELETE FROM kv WHERE key = %s", ph(1))); err != nil {
+		return nil, fmt.Errorf("failed to prepare delete statement: %w", err)
+	}
+	return ss, nil
+}
+
+func upsertSQL(ph placeholder) string {
+	return fmt.Sprintf(
+		"INSERT INTO kv (key, value) VALUES (%s, %s) ON CONFLICT (key) DO UPDATE SET value = excluded.value",
+		ph(1), ph(2),
+	)
+}
+
+func (ss *sqlStore) Get(key string) ([]byte, error) {
+	var value []byte
+	err := ss.getStmt.QueryRow([]byte(key)).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	return value, nil
+}
+
+func (ss *sqlStore) Put(key string, value []byte) error {
+	if err := store.CheckSizes(key, value); err != nil {
+		return err
+	}
+	if _, err := ss.upsertStmt.Exec([]byte(key), value); err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+	return nil
+}
+
+func (ss *sqlStore) Delete(key string) error {
+	if _, err := ss.deleteStmt.Exec([]byte(key)); err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+	return nil
+}
+
+type sqlMutation struct {
+	puts    map[string][]byte
+	deletes map[string]struct{}
+	order   []string
+	err     error // first size-cap violation, returned by Replay and CommitBatch
+}
+
+func (sm *sqlMutation) Put(key string, value []byte) {
+	if err := store.CheckSizes(key, value); err != nil {
+		if sm.err == nil {
+			sm.err = err
+		}
+		return
+	}
+	delete(sm.deletes, key)
+	if _, exists := sm.puts[key]; !exists {
+		sm.order = append(sm.order, key)
+	}
+	sm.puts[key] = value
+}
+
+func (sm *sqlMutation) Delete(key string) {
+	delete(sm.puts, key)
+	if _, exists := sm.deletes[key]; !exists {
+		sm.order = append(sm.order, key)
+	}
+	sm.deletes[key] = struct{}{}
+}
+
+func (sm *sqlMutation) Replay(r store.BatchReplay) error {
+	if sm.err != nil {
+		return sm.err
+	}
+	for _, key := range sm.order {
+		var err error
+		if val, ok := sm.puts[key]; ok {
+			err = r.Put(key, val)
+		} else {
+			err = r.Delete(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ss *sqlStore) BeginBatch() store.BatchMutation {
+	return &sqlMutation{
+		puts:    make(map[string][]byte),
+		deletes: make(map[string]struct{}),
+	}
+}
+
+// CommitBatch applies the batch inside a single database transaction so it
+// is atomic: either every mutation lands, or none do.
+func (ss *sqlStore) CommitBatch(batch store.BatchMutation) error {
+	sm, ok := batch.(*sqlMutation)
+	if !ok {
+		return fmt.Errorf("invalid batch mutation type: %T", batch)
+	}
+	if sm.err != nil {
+		return sm.err
+	}
+	tx, err := ss.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("sql error: failed to begin transaction: %w", err)
+	}
+	upsert := tx.Stmt(ss.upsertStmt)
+	del := tx.Stmt(ss.deleteStmt)
+	for _, key := range sm.order {
+		var err error
+		if val, ok := sm.puts[key]; ok {
+			_, err = upsert.Exec([]byte(key), val)
+		} else {
+			_, err = del.Exec([]byte(key))
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sql error: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sql error: failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+type sqlIterator struct {
+	rows   *sql.Rows
+	key    string
+	value  []byte
+	err    error
+	closed bool
+}
+
+func (si *sqlIterator) Next() bool {
+	if si.closed {
+		panic("next() called on closed iterator")
+	}
+	if si.rows == nil || !si.rows.Next() {
+		return false
+	}
+	var key []byte
+	if err := si.rows.Scan(&key, &si.value); err != nil {
+		si.err = err
+		return false
+	}
+	si.key = string(key)
+	return true
+}
+
+func (si *sqlIterator) Key() string { return si.key }
+
+func (si *sqlIterator) Value() []byte { return si.value }
+
+func (si *sqlIterator) Close() error {
+	si.closed = true
+	if si.rows == nil {
+		return si.err
+	}
+	if err := si.rows.Err(); err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+	return si.rows.Close()
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so rangeScan can serve
+// plain scans, Snapshot scans, and Update-transaction scans alike.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// rangeScan returns keys in [start, end) ordering, matching leveldb's
+// byte-lexicographic util.Range semantics. An empty start/end means
+// unbounded on that side.
+func rangeScan(q queryer, ph placeholder, start, end string) store.Iterator {
+	var b strings.Builder
+	b.WriteString("SELECT key, value FROM kv")
+	var args []interface{}
+	n := 1
+	if start != "" {
+		b.WriteString(fmt.Sprintf(" WHERE key >= %s", ph(n)))
+		args = append(args, []byte(start))
+		n++
+	}
+	if end != "" {
+		if n == 1 {
+			b.WriteString(" WHERE")
+		} else {
+			b.WriteString(" AND")
+		}
+		b.WriteString(fmt.Sprintf(" key < %s", ph(n)))
+		args = append(args, []byte(end))
+	}
+	b.WriteString(" ORDER BY key")
+	rows, err := q.Query(b.String(), args...)
+	if err != nil {
+		return &sqlIterator{err: fmt.Errorf("sql error: %w", err)}
+	}
+	return &sqlIterator{rows: rows}
+}
+
+// prefixScan returns every key sharing the given prefix, ordered the same
+// way rangeScan orders its results.
+func prefixScan(q queryer, ph placeholder, prefix string) store.Iterator {
+	lo, hi := prefixRange(prefix)
+	return rangeScan(q, ph, lo, hi)
+}
+
+func (ss *sqlStore) RangeScan(start, end string) store.Iterator {
+	return rangeScan(ss.db, ss.ph, start, end)
+}
+
+func (ss *sqlStore) PrefixScan(prefix string) store.Iterator {
+	return prefixScan(ss.db, ss.ph, prefix)
+}
+
+// prefixRange computes the [lo, hi) range covering every key with the given
+// prefix: lo is the prefix itself, hi is the prefix with its last byte
+// incremented (with carry), or "" if the prefix is all 0xFF bytes (i.e. the
+// range is unbounded above).
+func prefixRange(prefix string) (lo, hi string) {
+	b := []byte(prefix)
+	hib := make([]byte, len(b))
+	copy(hib, b)
+	for i := len(hib) - 1; i >= 0; i-- {
+		if hib[i] < 0xFF {
+			hib[i]++
+			return prefix, string(hib[:i+1])
+		}
+	}
+	return prefix, ""
+}
+
+// sqlSnapshot is a consistent read-only view backed by a read-only,
+// repeatable-read transaction, released via Close.
+type sqlSnapshot struct {
+	tx *sql.Tx
+	ph placeholder
+}
+
+func (sn *sqlSnapshot) Get(key string) ([]byte, error) {
+	var value []byte
+	row := sn.tx.QueryRow(fmt.Sprintf("SELECT value FROM kv WHERE key = %s", sn.ph(1)), []byte(key))
+	if err := row.Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	return value, nil
+}
+
+func (sn *sqlSnapshot) RangeScan(start, end string) store.Iterator {
+	return rangeScan(sn.tx, sn.ph, start, end)
+}
+
+func (sn *sqlSnapshot) PrefixScan(prefix string) store.Iterator {
+	return prefixScan(sn.tx, sn.ph, prefix)
+}
+
+func (sn *sqlSnapshot) Close() error {
+	return sn.tx.Rollback()
+}
+
+// Snapshot opens a read-only, repeatable-read transaction so every read
+// through it observes the same point-in-time view of the table. sqlite's
+// database/sql driver rejects BeginTx with a non-default isolation level
+// or ReadOnly: true, so on that dialect Snapshot opens a plain transaction
+// instead — sqlite already gives any transaction a consistent snapshot
+// from BEGIN.
+func (ss *sqlStore) Snapshot() (store.Snapshot, error) {
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	if ss.sqlite {
+		opts = nil
+	}
+	tx, err := ss.db.BeginTx(context.Background(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("sql error: failed to begin snapshot transaction: %w", err)
+	}
+	return &sqlSnapshot{tx: tx, ph: ss.ph}, nil
+}
+
+// sqlTxn is the Txn handed to the function passed to Update, backed
+// directly by a *sql.Tx so reads see the transaction's own uncommitted
+// writes the way the database already guarantees.
+type sqlTxn struct {
+	tx *sql.Tx
+	ph placeholder
+}
+
+func (t *sqlTxn) Get(key string) ([]byte, error) {
+	var value []byte
+	row := t.tx.QueryRow(fmt.Sprintf("SELECT value FROM kv WHERE key = %s", t.ph(1)), []byte(key))
+	if err := row.Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("sql error: %w", err)
+	}
+	return value, nil
+}
+
+func (t *sqlTxn) Put(key string, value []byte) error {
+	if err := store.CheckSizes(key, value); err != nil {
+		return err
+	}
+	_, err := t.tx.Exec(upsertSQL(t.ph), []byte(key), value)
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+	return nil
+}
+
+func (t *sqlTxn) Delete(key string) error {
+	_, err := t.tx.Exec(fmt.Sprintf("DELETE FROM kv WHERE key = %s", t.ph(1)), []byte(key))
+	if err != nil {
+		return fmt.Errorf("sql error: %w", err)
+	}
+	return nil
+}
+
+// isConflictErr reports whether err looks like a concurrency conflict
+// raised by the database (a serializable-isolation rollback in Postgres, or
+// SQLITE_BUSY from sqlite) rather than some other failure.
+func isConflictErr(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "could not serialize access") ||
+		strings.Contains(msg, "SQLSTATE 40001") ||
+		strings.Contains(msg, "database is locked")
+}
+
+// Update runs fn against a serializable transaction and commits its writes
+// atomically if fn returns nil. It returns store.ErrConflict if the
+// database detects the transaction conflicted with a concurrent one, so
+// callers should be prepared to call Update more than once. sqlite's
+// database/sql driver rejects BeginTx with a non-default isolation level,
+// so on that dialect Update opens a plain transaction instead — sqlite
+// already serializes writers against a single database-wide writer lock.
+func (ss *sqlStore) Update(fn func(store.Txn) error) error {
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	if ss.sqlite {
+		opts = nil
+	}
+	tx, err := ss.db.BeginTx(context.Background(), opts)
+	if err != nil {
+		return fmt.Errorf("sql error: failed to begin transaction: %w", err)
+	}
+	if err := fn(&sqlTxn{tx: tx, ph: ss.ph}); err != nil {
+		tx.Rollback()
+		if isConflictErr(err) {
+			return store.ErrConflict
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		if isConflictErr(err) {
+			return store.ErrConflict
+		}
+		return fmt.Errorf("sql error: failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/morgangallant/trading/store"
+)
+
+// openTestSQLite opens a throwaway in-memory sqlite store for a test.
+func openTestSQLite(t *testing.T) store.Store {
+	t.Helper()
+	ss, err := openSQLite("sqlite://:memory:")
+	if err != nil {
+		t.Fatalf("openSQLite: %v", err)
+	}
+	return ss
+}
+
+func TestSnapshotSQLite(t *testing.T) {
+	ss := openTestSQLite(t)
+	if err := ss.Put("k", []byte("v1")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	snap, err := ss.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	defer snap.Close()
+
+	if err := ss.Put("k", []byte("v2")); err != nil {
+		t.Fatalf("Put after snapshot: %v", err)
+	}
+
+	val, err := snap.Get("k")
+	if err != nil {
+		t.Fatalf("snap.Get: %v", err)
+	}
+	if string(val) != "v1" {
+		t.Errorf("snapshot read %q, want the pre-snapshot value %q", val, "v1")
+	}
+}
+
+func TestUpdateSQLite(t *testing.T) {
+	ss := openTestSQLite(t)
+
+	err := ss.Update(func(txn store.Txn) error {
+		return txn.Put("k", []byte("v"))
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	val, err := ss.Get("k")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "v" {
+		t.Errorf("Get = %q, want %q", val, "v")
+	}
+}
@@ -0,0 +1,23 @@
+package strategy
+
+import "github.com/morgangallant/trading/store"
+
+// Checkpoint scopes a Strategy's durable state to its own "strat/<name>/"
+// key prefix, so checkpointing through the underlying Store's Update/
+// Snapshot primitives makes restarts deterministic: a strategy reads and
+// writes only the keys under its own prefix.
+type Checkpoint struct {
+	Store store.Store
+	Name  string
+}
+
+// NewCheckpoint returns a Checkpoint scoped to name.
+func NewCheckpoint(s store.Store, name string) *Checkpoint {
+	return &Checkpoint{Store: s, Name: name}
+}
+
+// Key returns the fully-qualified store key for a suffix under this
+// strategy's prefix, e.g. Key("state") -> "strat/momentum/state".
+func (c *Checkpoint) Key(suffix string) string {
+	return "strat/" + c.Name + "/" + suffix
+}
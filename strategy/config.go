@@ -0,0 +1,44 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// StrategyConfig is one entry in a Config's strategies list.
+type StrategyConfig struct {
+	Name   string                 `yaml:"name"`
+	Params map[string]interface{} `yaml:"params"`
+}
+
+// RiskConfig holds the limits enforced by a RiskGate. Zero means
+// unlimited.
+type RiskConfig struct {
+	MaxPositionQty float64 `yaml:"max_position_qty"`
+	MaxNotional    float64 `yaml:"max_notional"`
+	MaxDailyLoss   float64 `yaml:"max_daily_loss"`
+}
+
+// Config is the top-level shape of a Runner's YAML config file: which
+// symbols to subscribe to and which strategies to load, in place of
+// hardcoding either.
+type Config struct {
+	Symbols    []string         `yaml:"symbols"`
+	Strategies []StrategyConfig `yaml:"strategies"`
+	Risk       RiskConfig       `yaml:"risk"`
+}
+
+// LoadConfig reads and parses a Config from a YAML file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("strategy: failed to read config: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("strategy: failed to parse config: %w", err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,35 @@
+// Package noop provides the "noop" strategy: it never emits an Intent.
+// It exists as a config-file-selectable placeholder for nodes that should
+// only log and journal market data without trading on it.
+package noop
+
+import (
+	"context"
+
+	"github.com/alpacahq/alpaca-trade-api-go/alpaca"
+
+	"github.com/morgangallant/trading/strategy"
+)
+
+func init() {
+	strategy.Register("noop", New)
+}
+
+type noop struct{}
+
+// New implements strategy.Factory for the "noop" strategy.
+func New(params map[string]interface{}, cp *strategy.Checkpoint) (strategy.Strategy, error) {
+	return &noop{}, nil
+}
+
+func (noop) OnQuote(ctx context.Context, quote alpaca.StreamQuote, p strategy.Portfolio) []strategy.Intent {
+	return nil
+}
+
+func (noop) OnTradeUpdate(ctx context.Context, update alpaca.TradeUpdate, p strategy.Portfolio) []strategy.Intent {
+	return nil
+}
+
+func (noop) OnBar(ctx context.Context, bar strategy.Bar, p strategy.Portfolio) []strategy.Intent {
+	return nil
+}
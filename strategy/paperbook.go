@@ -0,0 +1,172 @@
+package strategy
+
+import (
+	"strconv"
+
+	"github.com/morgangallant/trading/store"
+)
+
+const (
+	paperCashKey     = "portfolio/cash"
+	paperPositionPfx = "portfolio/pos/"
+	paperCostPfx     = "portfolio/cost/" // per-symbol average entry price of the open position
+)
+
+func paperPositionKey(symbol string) string {
+	return paperPositionPfx + symbol
+}
+
+func paperCostKey(symbol string) string {
+	return paperCostPfx + symbol
+}
+
+// PaperBook is a Store-backed Portfolio: positions and cash live under a
+// "portfolio/" key prefix, updated atomically via the Store's Update
+// transactions so a strategy never observes a half-applied fill. It also
+// reports realized P&L on position-reducing fills to a RiskGate, so the
+// gate's max-daily-loss check has something other than zero to compare
+// against.
+type PaperBook struct {
+	store store.Store
+	risk  *RiskGate
+}
+
+// NewPaperBook builds a PaperBook backed by s, reporting realized P&L from
+// fills to risk so its max-daily-loss check can fire. risk may be nil, in
+// which case realized P&L is simply not tracked.
+func NewPaperBook(s store.Store, risk *RiskGate) *PaperBook {
+	return &PaperBook{store: s, risk: risk}
+}
+
+func parseFloatOr(b []byte, err error, fallback float64) float64 {
+	if err != nil {
+		return fallback
+	}
+	f, parseErr := strconv.ParseFloat(string(b), 64)
+	if parseErr != nil {
+		return fallback
+	}
+	return f
+}
+
+// Cash implements Portfolio.
+func (b *PaperBook) Cash() float64 {
+	val, err := b.store.Get(paperCashKey)
+	return parseFloatOr(val, err, 0)
+}
+
+// Position implements Portfolio.
+func (b *PaperBook) Position(symbol string) Position {
+	val, err := b.store.Get(paperPositionKey(symbol))
+	return Position{Symbol: symbol, Qty: parseFloatOr(val, err, 0)}
+}
+
+// Fill atomically applies a paper trade: qty shares of symbol at price,
+// updating the position, its cost basis, and cash in one Update
+// transaction. If the trade closes or reverses all or part of an existing
+// position, the realized gain or loss is reported to the RiskGate given
+// to NewPaperBook.
+//
+// store.Update's callback must be safe to run more than once, and its
+// writes only land if the transaction commits, so the realized amount is
+// captured from the callback and reported to the RiskGate once, after the
+// transaction commits, rather than from inside the callback itself.
+func (b *PaperBook) Fill(symbol string, side Side, qty, price float64) error {
+	var realized float64
+	err := b.store.Update(func(txn store.Txn) error {
+		posVal, err := txn.Get(paperPositionKey(symbol))
+		if err != nil && err != store.ErrKeyNotFound {
+			return err
+		}
+		pos := parseFloatOr(posVal, err, 0)
+
+		costVal, err := txn.Get(paperCostKey(symbol))
+		if err != nil && err != store.ErrKeyNotFound {
+			return err
+		}
+		avgCost := parseFloatOr(costVal, err, 0)
+
+		cashVal, err := txn.Get(paperCashKey)
+		if err != nil && err != store.ErrKeyNotFound {
+			return err
+		}
+		cash := parseFloatOr(cashVal, err, 0)
+
+		delta := qty
+		notional := qty * price
+		switch side {
+		case Buy:
+			cash -= notional
+		case Sell:
+			delta = -qty
+			cash += notional
+		}
+
+		var newPos, newCost float64
+		newPos, newCost, realized = applyFill(pos, avgCost, delta, price)
+
+		if err := txn.Put(paperPositionKey(symbol), []byte(strconv.FormatFloat(newPos, 'f', -1, 64))); err != nil {
+			return err
+		}
+		if err := txn.Put(paperCostKey(symbol), []byte(strconv.FormatFloat(newCost, 'f', -1, 64))); err != nil {
+			return err
+		}
+		return txn.Put(paperCashKey, []byte(strconv.FormatFloat(cash, 'f', -1, 64)))
+	})
+	if err != nil {
+		return err
+	}
+	if b.risk != nil && realized != 0 {
+		b.risk.RecordPnL(realized)
+	}
+	return nil
+}
+
+// applyFill folds a signed qty change (delta, positive for buys, negative
+// for sells) into a position carried at pos shares with an average entry
+// price of avgCost, using standard average-cost-basis accounting. It
+// returns the new position, its new average cost, and any P&L realized by
+// the part of delta that closed out existing exposure rather than adding
+// to it.
+func applyFill(pos, avgCost, delta, price float64) (newPos, newCost, realized float64) {
+	if pos == 0 || sameSign(pos, delta) {
+		newPos = pos + delta
+		if newPos == 0 {
+			return 0, 0, 0
+		}
+		newCost = (abs(pos)*avgCost + abs(delta)*price) / abs(newPos)
+		return newPos, newCost, 0
+	}
+
+	closing := abs(delta)
+	if abs(pos) < closing {
+		closing = abs(pos)
+	}
+	realized = sign(pos) * (price - avgCost) * closing
+	newPos = pos + delta
+
+	switch {
+	case abs(delta) < abs(pos):
+		newCost = avgCost // position shrank but didn't flip; basis unchanged
+	case abs(delta) == abs(pos):
+		newCost = 0 // flat
+	default:
+		newCost = price // reversed through flat into a new position at price
+	}
+	return newPos, newCost, realized
+}
+
+func sameSign(a, b float64) bool {
+	return (a > 0 && b > 0) || (a < 0 && b < 0)
+}
+
+func sign(f float64) float64 {
+	switch {
+	case f > 0:
+		return 1
+	case f < 0:
+		return -1
+	default:
+		return 0
+	}
+}
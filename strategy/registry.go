@@ -0,0 +1,41 @@
+package strategy
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Factory constructs a Strategy from its config section (the "params" map
+// from its entry in the config file) and a Checkpoint scoped to its name.
+type Factory func(params map[string]interface{}, cp *Checkpoint) (Strategy, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a strategy available under name. It is intended to be
+// called from a strategy package's init() function and panics if the name
+// is already registered, mirroring store.Register.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("strategy: Register factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("strategy: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New constructs the named strategy.
+func New(name string, params map[string]interface{}, cp *Checkpoint) (Strategy, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("strategy: unknown strategy %q", name)
+	}
+	return factory(params, cp)
+}
@@ -0,0 +1,70 @@
+package strategy
+
+import "sync"
+
+// RiskGate enforces portfolio-wide limits on intents before they reach an
+// OrderRouter: a maximum position size and notional per symbol, and a
+// maximum realized loss across the whole portfolio. The realized P&L that
+// the loss check compares against is a running total from the RiskGate's
+// creation; it is not reset at day boundaries.
+type RiskGate struct {
+	cfg RiskConfig
+
+	mu          sync.Mutex
+	realizedPnL float64
+}
+
+// NewRiskGate builds a RiskGate from the risk section of a Config.
+func NewRiskGate(cfg RiskConfig) *RiskGate {
+	return &RiskGate{cfg: cfg}
+}
+
+// RecordPnL adjusts the running realized P&L used by the max-daily-loss
+// check. Callers should report a fill's realized gain or loss here as it
+// happens. PaperBook does this for paper-mode fills; LiveRouter does not
+// book fills against any local Portfolio at all, so the max-daily-loss
+// check is currently inert in live mode.
+func (g *RiskGate) RecordPnL(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.realizedPnL += delta
+}
+
+// Allow reports whether intent may proceed given the portfolio's current
+// position and an estimated fill price (used for the notional check; pass
+// 0 if no price is available, which skips that check). On rejection it
+// also returns a human-readable reason for logging.
+func (g *RiskGate) Allow(intent Intent, p Portfolio, price float64) (bool, string) {
+	g.mu.Lock()
+	pnl := g.realizedPnL
+	g.mu.Unlock()
+
+	if g.cfg.MaxDailyLoss > 0 && pnl <= -g.cfg.MaxDailyLoss {
+		return false, "max daily loss breached"
+	}
+	if intent.Kind != IntentOrder {
+		return true, ""
+	}
+
+	projected := p.Position(intent.Symbol).Qty
+	switch intent.Side {
+	case Buy:
+		projected += intent.Qty
+	case Sell:
+		projected -= intent.Qty
+	}
+	if g.cfg.MaxPositionQty > 0 && abs(projected) > g.cfg.MaxPositionQty {
+		return false, "max position exceeded"
+	}
+	if g.cfg.MaxNotional > 0 && price > 0 && abs(projected)*price > g.cfg.MaxNotional {
+		return false, "max notional exceeded"
+	}
+	return true, ""
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,92 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alpacahq/alpaca-trade-api-go/alpaca"
+	"github.com/shopspring/decimal"
+
+	"github.com/morgangallant/trading/tickstore"
+)
+
+// OrderRouter submits a surviving Intent for execution.
+type OrderRouter interface {
+	Submit(ctx context.Context, intent Intent) error
+}
+
+// PaperRouter fills intents immediately against the last known quote and
+// books the result in a PaperBook, so strategies can be run end-to-end
+// without touching a real account.
+type PaperRouter struct {
+	ticks *tickstore.Store
+	book  *PaperBook
+}
+
+// NewPaperRouter builds a PaperRouter that fills against ticks and records
+// positions into book.
+func NewPaperRouter(ticks *tickstore.Store, book *PaperBook) *PaperRouter {
+	return &PaperRouter{ticks: ticks, book: book}
+}
+
+// Submit implements OrderRouter.
+func (r *PaperRouter) Submit(ctx context.Context, intent Intent) error {
+	if intent.Kind == IntentCancel {
+		// Paper fills happen synchronously, so there's never anything
+		// still in flight to cancel.
+		return nil
+	}
+	tick, err := r.ticks.Latest(intent.Symbol)
+	if err != nil {
+		return fmt.Errorf("strategy: no quote to fill %s against: %w", intent.Symbol, err)
+	}
+	if intent.LimitPrice > 0 {
+		switch intent.Side {
+		case Buy:
+			if intent.LimitPrice < tick.Price {
+				return nil // limit not met
+			}
+		case Sell:
+			if intent.LimitPrice > tick.Price {
+				return nil
+			}
+		}
+	}
+	return r.book.Fill(intent.Symbol, intent.Side, intent.Qty, tick.Price)
+}
+
+// LiveRouter submits intents as real orders through an Alpaca client.
+type LiveRouter struct {
+	client *alpaca.Client
+}
+
+// NewLiveRouter builds a LiveRouter backed by client.
+func NewLiveRouter(client *alpaca.Client) *LiveRouter {
+	return &LiveRouter{client: client}
+}
+
+// Submit implements OrderRouter.
+func (r *LiveRouter) Submit(ctx context.Context, intent Intent) error {
+	if intent.Kind == IntentCancel {
+		return r.client.CancelOrder(intent.CancelOrderID)
+	}
+
+	orderType := alpaca.Market
+	var limitPrice *decimal.Decimal
+	if intent.LimitPrice > 0 {
+		orderType = alpaca.Limit
+		lp := decimal.NewFromFloat(intent.LimitPrice)
+		limitPrice = &lp
+	}
+
+	symbol := intent.Symbol
+	_, err := r.client.PlaceOrder(alpaca.PlaceOrderRequest{
+		AssetKey:    &symbol,
+		Qty:         decimal.NewFromFloat(intent.Qty),
+		Side:        alpaca.Side(intent.Side),
+		Type:        orderType,
+		TimeInForce: alpaca.Day,
+		LimitPrice:  limitPrice,
+	})
+	return err
+}
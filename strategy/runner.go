@@ -0,0 +1,79 @@
+package strategy
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/alpacahq/alpaca-trade-api-go/alpaca"
+)
+
+// Runner fans stream events out to every registered Strategy, aggregates
+// the Intents they emit, runs each one past a RiskGate, and submits
+// survivors through an OrderRouter.
+type Runner struct {
+	strategies []Strategy
+	router     OrderRouter
+	risk       *RiskGate
+	portfolio  Portfolio
+
+	// mu serializes dispatch so strategies are never called concurrently
+	// with one another and all observe the same Portfolio snapshot for a
+	// given event.
+	mu sync.Mutex
+}
+
+// NewRunner builds a Runner. portfolio is shared across every call: it
+// should reflect fills as they're applied by router.
+func NewRunner(strategies []Strategy, router OrderRouter, risk *RiskGate, portfolio Portfolio) *Runner {
+	return &Runner{strategies: strategies, router: router, risk: risk, portfolio: portfolio}
+}
+
+// OnQuote dispatches a streamed quote to every strategy and submits the
+// resulting intents. The quote's bid/ask midpoint is used as the price for
+// the risk gate's notional check.
+func (r *Runner) OnQuote(ctx context.Context, quote alpaca.StreamQuote) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var intents []Intent
+	for _, s := range r.strategies {
+		intents = append(intents, s.OnQuote(ctx, quote, r.portfolio)...)
+	}
+	r.submit(ctx, intents, (quote.BidPrice+quote.AskPrice)/2)
+}
+
+// OnTradeUpdate dispatches a trade update to every strategy and submits
+// the resulting intents.
+func (r *Runner) OnTradeUpdate(ctx context.Context, update alpaca.TradeUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var intents []Intent
+	for _, s := range r.strategies {
+		intents = append(intents, s.OnTradeUpdate(ctx, update, r.portfolio)...)
+	}
+	r.submit(ctx, intents, 0)
+}
+
+// OnBar dispatches a bar to every strategy and submits the resulting
+// intents.
+func (r *Runner) OnBar(ctx context.Context, bar Bar) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var intents []Intent
+	for _, s := range r.strategies {
+		intents = append(intents, s.OnBar(ctx, bar, r.portfolio)...)
+	}
+	r.submit(ctx, intents, bar.Close)
+}
+
+func (r *Runner) submit(ctx context.Context, intents []Intent, price float64) {
+	for _, intent := range intents {
+		if ok, reason := r.risk.Allow(intent, r.portfolio, price); !ok {
+			log.Printf("strategy: risk gate rejected intent for %s: %s", intent.Symbol, reason)
+			continue
+		}
+		if err := r.router.Submit(ctx, intent); err != nil {
+			log.Printf("strategy: failed to submit intent for %s: %v", intent.Symbol, err)
+		}
+	}
+}
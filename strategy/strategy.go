@@ -0,0 +1,74 @@
+// Package strategy turns streamed market data into trade decisions: it
+// defines the Strategy interface strategies implement, the Intents they
+// emit, and a Runner that fans stream events out to every registered
+// strategy, enforces a risk gate, and submits surviving intents through an
+// OrderRouter.
+package strategy
+
+import (
+	"context"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/alpaca"
+)
+
+// Side is the direction of an order.
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// IntentKind distinguishes the two things a Strategy can ask for.
+type IntentKind int
+
+const (
+	IntentOrder IntentKind = iota
+	IntentCancel
+)
+
+// Intent is a strategy's request to trade: either place a new order (Side,
+// Qty, and optionally LimitPrice) or cancel one already in flight
+// (CancelOrderID).
+type Intent struct {
+	Kind IntentKind
+
+	Symbol     string
+	Side       Side
+	Qty        float64
+	LimitPrice float64 // zero means a market order
+
+	CancelOrderID string
+}
+
+// Position is a holding in a single symbol.
+type Position struct {
+	Symbol string
+	Qty    float64
+}
+
+// Portfolio is the read-only view of current holdings and cash passed to
+// strategies so they can size their intents.
+type Portfolio interface {
+	Cash() float64
+	Position(symbol string) Position
+}
+
+// Bar is an OHLCV bar for a symbol over some interval.
+type Bar struct {
+	Symbol                 string
+	Open, High, Low, Close float64
+	Volume                 int64
+	Time                   time.Time
+}
+
+// Strategy reacts to market data and trade events by emitting Intents. A
+// Runner never calls a given Strategy's methods concurrently with one
+// another, so implementations don't need their own locking around state
+// that's only touched from these callbacks.
+type Strategy interface {
+	OnQuote(ctx context.Context, quote alpaca.StreamQuote, p Portfolio) []Intent
+	OnTradeUpdate(ctx context.Context, update alpaca.TradeUpdate, p Portfolio) []Intent
+	OnBar(ctx context.Context, bar Bar, p Portfolio) []Intent
+}
@@ -0,0 +1,86 @@
+package tickstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// blockHeaderSize is the first-timestamp (8 bytes), first-price (8 bytes),
+// and tick count (4 bytes) every block starts with, ahead of the
+// bit-packed body.
+const blockHeaderSize = 8 + 8 + 4
+
+// encodeBlock packs ticks into a compact, chronologically-ordered block:
+// a header carrying the first tick's timestamp/price and the tick count,
+// followed by a Gorilla-style delta-of-delta timestamp and XOR-compressed
+// price for every subsequent tick. ticks must be sorted by Time and must
+// not be empty.
+func encodeBlock(ticks []Tick) []byte {
+	header := make([]byte, blockHeaderSize)
+	binary.BigEndian.PutUint64(header[0:8], uint64(ticks[0].Time.UnixNano()))
+	binary.BigEndian.PutUint64(header[8:16], math.Float64bits(ticks[0].Price))
+	binary.BigEndian.PutUint32(header[16:20], uint32(len(ticks)))
+
+	w := &bitWriter{}
+	prevTime := ticks[0].Time.UnixNano()
+	var prevDelta int64
+	prevPrice := math.Float64bits(ticks[0].Price)
+	prevLeading, prevTrailing := -1, -1
+
+	for _, tick := range ticks[1:] {
+		t := tick.Time.UnixNano()
+		delta := t - prevTime
+		writeTimestampDoD(w, delta-prevDelta)
+		prevDelta, prevTime = delta, t
+
+		price := math.Float64bits(tick.Price)
+		writeValueXOR(w, price^prevPrice, &prevLeading, &prevTrailing)
+		prevPrice = price
+	}
+
+	return append(header, w.buf...)
+}
+
+// decodeBlock is the inverse of encodeBlock.
+func decodeBlock(data []byte) ([]Tick, error) {
+	if len(data) < blockHeaderSize {
+		return nil, fmt.Errorf("block too short (%d bytes)", len(data))
+	}
+	firstNanos := int64(binary.BigEndian.Uint64(data[0:8]))
+	firstBits := binary.BigEndian.Uint64(data[8:16])
+	count := binary.BigEndian.Uint32(data[16:20])
+	if count == 0 {
+		return nil, fmt.Errorf("block header claims zero ticks")
+	}
+
+	ticks := make([]Tick, 1, count)
+	ticks[0] = Tick{Time: time.Unix(0, firstNanos).UTC(), Price: math.Float64frombits(firstBits)}
+	if count == 1 {
+		return ticks, nil
+	}
+
+	r := &bitReader{buf: data[blockHeaderSize:]}
+	prevTime, prevPrice := firstNanos, firstBits
+	var prevDelta int64
+	prevLeading, prevTrailing := -1, -1
+
+	for i := uint32(1); i < count; i++ {
+		dod, err := readTimestampDoD(r)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt timestamp at tick %d: %w", i, err)
+		}
+		delta := prevDelta + dod
+		t := prevTime + delta
+
+		price, err := readValueXOR(r, prevPrice, &prevLeading, &prevTrailing)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt price at tick %d: %w", i, err)
+		}
+
+		ticks = append(ticks, Tick{Time: time.Unix(0, t).UTC(), Price: math.Float64frombits(price)})
+		prevDelta, prevTime, prevPrice = delta, t, price
+	}
+	return ticks, nil
+}
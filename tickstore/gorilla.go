@@ -0,0 +1,219 @@
+package tickstore
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// bitWriter accumulates bits MSB-first into a growable byte slice.
+type bitWriter struct {
+	buf   []byte
+	nbits int
+}
+
+func (w *bitWriter) writeBit(bit byte) {
+	idx := w.nbits / 8
+	if idx == len(w.buf) {
+		w.buf = append(w.buf, 0)
+	}
+	if bit != 0 {
+		w.buf[idx] |= 1 << uint(7-w.nbits%8)
+	}
+	w.nbits++
+}
+
+// writeBits writes the low nbits bits of value, most significant bit
+// first.
+func (w *bitWriter) writeBits(value uint64, nbits int) {
+	for i := nbits - 1; i >= 0; i-- {
+		w.writeBit(byte((value >> uint(i)) & 1))
+	}
+}
+
+// bitReader is the mirror image of bitWriter.
+type bitReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *bitReader) readBit() (byte, error) {
+	idx := r.pos / 8
+	if idx >= len(r.buf) {
+		return 0, fmt.Errorf("unexpected end of block")
+	}
+	bit := (r.buf[idx] >> uint(7-r.pos%8)) & 1
+	r.pos++
+	return bit, nil
+}
+
+func (r *bitReader) readBits(nbits int) (uint64, error) {
+	var v uint64
+	for i := 0; i < nbits; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v = (v << 1) | uint64(bit)
+	}
+	return v, nil
+}
+
+// writeTimestampDoD encodes a delta-of-delta of two consecutive tick
+// timestamps using the variable-width buckets from the Gorilla paper: a
+// single 0 bit for no change, then progressively wider buckets for larger
+// swings, falling back to a full 64-bit value for anything that doesn't
+// fit (e.g. a gap in the stream). The fallback must be 64 bits, not the
+// paper's original 32: dod is a delta-of-delta of nanosecond timestamps,
+// so an inter-tick gap change of only ~2.15s — unremarkable during a quiet
+// period or a trading halt — already overflows int32, and since deltas
+// accumulate across a block, one truncated value corrupts every tick after
+// it.
+func writeTimestampDoD(w *bitWriter, dod int64) {
+	switch {
+	case dod == 0:
+		w.writeBit(0)
+	case dod >= -63 && dod <= 64:
+		w.writeBits(0b10, 2)
+		w.writeBits(uint64(dod+63), 7)
+	case dod >= -255 && dod <= 256:
+		w.writeBits(0b110, 3)
+		w.writeBits(uint64(dod+255), 9)
+	case dod >= -2047 && dod <= 2048:
+		w.writeBits(0b1110, 4)
+		w.writeBits(uint64(dod+2047), 12)
+	default:
+		w.writeBits(0b1111, 4)
+		w.writeBits(uint64(dod), 64)
+	}
+}
+
+func readTimestampDoD(r *bitReader) (int64, error) {
+	b, err := r.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		return 0, nil
+	}
+	b, err = r.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		v, err := r.readBits(7)
+		if err != nil {
+			return 0, err
+		}
+		return int64(v) - 63, nil
+	}
+	b, err = r.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		v, err := r.readBits(9)
+		if err != nil {
+			return 0, err
+		}
+		return int64(v) - 255, nil
+	}
+	b, err = r.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		v, err := r.readBits(12)
+		if err != nil {
+			return 0, err
+		}
+		return int64(v) - 2047, nil
+	}
+	v, err := r.readBits(64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(v), nil
+}
+
+// writeValueXOR encodes price's bits as an XOR against the previous price's
+// bits: 0 if unchanged, otherwise the meaningful (non-zero) bits, reusing
+// the previous tick's leading/trailing-zero window when it still fits so
+// most ticks only cost a couple of control bits plus the payload.
+func writeValueXOR(w *bitWriter, xor uint64, prevLeading, prevTrailing *int) {
+	if xor == 0 {
+		w.writeBit(0)
+		return
+	}
+	w.writeBit(1)
+
+	leading := bits.LeadingZeros64(xor)
+	trailing := bits.TrailingZeros64(xor)
+	if leading > 31 {
+		leading = 31 // 5-bit field below caps out at 31
+	}
+
+	if *prevLeading >= 0 && leading >= *prevLeading && trailing >= *prevTrailing {
+		w.writeBit(0)
+		meaningful := 64 - *prevLeading - *prevTrailing
+		w.writeBits(xor>>uint(*prevTrailing), meaningful)
+		return
+	}
+
+	w.writeBit(1)
+	meaningful := 64 - leading - trailing
+	lenField := meaningful
+	if lenField == 64 {
+		lenField = 0 // 64 doesn't fit in 6 bits, so 0 stands in for it
+	}
+	w.writeBits(uint64(leading), 5)
+	w.writeBits(uint64(lenField), 6)
+	w.writeBits(xor>>uint(trailing), meaningful)
+	*prevLeading = leading
+	*prevTrailing = trailing
+}
+
+func readValueXOR(r *bitReader, prevPrice uint64, prevLeading, prevTrailing *int) (uint64, error) {
+	b, err := r.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		return prevPrice, nil
+	}
+
+	b, err = r.readBit()
+	if err != nil {
+		return 0, err
+	}
+
+	var leading, trailing, meaningful int
+	if b == 0 {
+		if *prevLeading < 0 {
+			return 0, fmt.Errorf("reused a leading/trailing-zero window before one was established")
+		}
+		leading, trailing = *prevLeading, *prevTrailing
+		meaningful = 64 - leading - trailing
+	} else {
+		lv, err := r.readBits(5)
+		if err != nil {
+			return 0, err
+		}
+		mv, err := r.readBits(6)
+		if err != nil {
+			return 0, err
+		}
+		leading = int(lv)
+		meaningful = int(mv)
+		if meaningful == 0 {
+			meaningful = 64
+		}
+		trailing = 64 - leading - meaningful
+		*prevLeading, *prevTrailing = leading, trailing
+	}
+
+	payload, err := r.readBits(meaningful)
+	if err != nil {
+		return 0, err
+	}
+	return prevPrice ^ (payload << uint(trailing)), nil
+}
@@ -0,0 +1,39 @@
+package tickstore
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBlockRoundTripLargeGap covers a delta-of-delta swing far beyond the
+// old 32-bit fallback's ~2.147s range: a multi-second gap in an otherwise
+// steady stream of ticks, as seen around a quiet period or a trading halt.
+func TestBlockRoundTripLargeGap(t *testing.T) {
+	base := time.Unix(1700000000, 0).UTC()
+	ticks := []Tick{
+		{Time: base, Price: 100.0},
+		{Time: base.Add(time.Second), Price: 100.1},
+		{Time: base.Add(time.Second + time.Millisecond), Price: 100.2},
+		{Time: base.Add(10 * time.Second), Price: 100.3},
+		{Time: base.Add(10*time.Second + time.Millisecond), Price: 100.4},
+	}
+
+	encoded := encodeBlock(ticks)
+	decoded, err := decodeBlock(encoded)
+	if err != nil {
+		t.Fatalf("decodeBlock: %v", err)
+	}
+
+	if len(decoded) != len(ticks) {
+		t.Fatalf("decoded %d ticks, want %d", len(decoded), len(ticks))
+	}
+	for i, want := range ticks {
+		got := decoded[i]
+		if !got.Time.Equal(want.Time) {
+			t.Errorf("tick %d time = %v, want %v", i, got.Time, want.Time)
+		}
+		if got.Price != want.Price {
+			t.Errorf("tick %d price = %v, want %v", i, got.Price, want.Price)
+		}
+	}
+}
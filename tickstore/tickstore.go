@@ -0,0 +1,282 @@
+// Package tickstore persists streamed quotes into a store.Store as a
+// compact time series, keyed so a plain RangeScan returns them in
+// chronological order, and packed with a Gorilla-style encoding that keeps
+// a block of many ticks well under the store's per-value size cap.
+package tickstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/morgangallant/trading/store"
+)
+
+const (
+	blockKeyPrefix = "tk/" // tk/<symbol>/<big-endian uint64 unix-nanos>
+	latestPrefix   = "tl/" // tl/<symbol>
+
+	// defaultMaxTicks and defaultMaxBytes bound how large a single block
+	// (and so a single value written to the Store) is allowed to grow
+	// before it's flushed, keeping every block comfortably under
+	// store.MaxValueSize.
+	defaultMaxTicks = 512
+	defaultMaxBytes = 8 << 10 // 8 KiB
+
+	defaultFlushInterval = time.Second
+)
+
+// Tick is a single timestamped price observation.
+type Tick struct {
+	Time  time.Time
+	Price float64
+}
+
+func blockKey(symbol string, t time.Time) string {
+	var nanos [8]byte
+	binary.BigEndian.PutUint64(nanos[:], uint64(t.UnixNano()))
+	return blockKeyPrefix + symbol + "/" + string(nanos[:])
+}
+
+func symbolPrefix(symbol string) string {
+	return blockKeyPrefix + symbol + "/"
+}
+
+func latestKey(symbol string) string {
+	return latestPrefix + symbol
+}
+
+func encodeLatest(t Tick) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[0:8], uint64(t.Time.UnixNano()))
+	binary.BigEndian.PutUint64(buf[8:16], math.Float64bits(t.Price))
+	return buf
+}
+
+func decodeLatest(buf []byte) (Tick, error) {
+	if len(buf) != 16 {
+		return Tick{}, fmt.Errorf("tickstore: malformed latest record (%d bytes)", len(buf))
+	}
+	return Tick{
+		Time:  time.Unix(0, int64(binary.BigEndian.Uint64(buf[0:8]))).UTC(),
+		Price: math.Float64frombits(binary.BigEndian.Uint64(buf[8:16])),
+	}, nil
+}
+
+// pendingBlock is a completed block waiting for the background flusher to
+// commit it to the Store.
+type pendingBlock struct {
+	key    string
+	symbol string
+	ticks  []Tick
+}
+
+// Store persists ticks for any number of symbols into an underlying
+// store.Store. Call Close when done with it to flush and stop the
+// background flusher.
+type Store struct {
+	s store.Store
+
+	mu      sync.Mutex
+	open    map[string][]Tick // symbol -> ticks not yet forming a full block
+	pending []pendingBlock    // completed blocks not yet committed
+	latest  map[string]Tick   // symbol -> most recent tick, not yet committed
+
+	maxTicks int
+	maxBytes int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a Store layered on s and starts its background flusher,
+// which amortizes disk writes by batching completed blocks (and latest-
+// tick updates) across all symbols on a fixed interval rather than writing
+// on every Append.
+func New(s store.Store) *Store {
+	ts := &Store{
+		s:        s,
+		open:     make(map[string][]Tick),
+		latest:   make(map[string]Tick),
+		maxTicks: defaultMaxTicks,
+		maxBytes: defaultMaxBytes,
+		stop:     make(chan struct{}),
+	}
+	ts.wg.Add(1)
+	go ts.flushLoop(defaultFlushInterval)
+	return ts
+}
+
+// Append records a tick for symbol. Ticks for a given symbol must be
+// appended in chronological order.
+func (ts *Store) Append(symbol string, tick Tick) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.latest[symbol] = tick
+	ticks := append(ts.open[symbol], tick)
+
+	full := len(ticks) >= ts.maxTicks
+	if !full && len(encodeBlock(ticks)) >= ts.maxBytes {
+		full = true
+	}
+	if !full {
+		ts.open[symbol] = ticks
+		return nil
+	}
+
+	ts.pending = append(ts.pending, pendingBlock{
+		key:    blockKey(symbol, ticks[0].Time),
+		symbol: symbol,
+		ticks:  ticks,
+	})
+	delete(ts.open, symbol)
+	return nil
+}
+
+// flushLoop periodically commits whatever blocks and latest-tick updates
+// have accumulated since the last flush, in a single batch.
+func (ts *Store) flushLoop(interval time.Duration) {
+	defer ts.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			ts.flush()
+		case <-ts.stop:
+			ts.flush()
+			return
+		}
+	}
+}
+
+func (ts *Store) flush() {
+	ts.mu.Lock()
+	pending := ts.pending
+	ts.pending = nil
+	latest := ts.latest
+	ts.latest = make(map[string]Tick, len(latest))
+	ts.mu.Unlock()
+
+	if len(pending) == 0 && len(latest) == 0 {
+		return
+	}
+
+	batch := ts.s.BeginBatch()
+	for _, pb := range pending {
+		batch.Put(pb.key, encodeBlock(pb.ticks))
+	}
+	for symbol, tick := range latest {
+		batch.Put(latestKey(symbol), encodeLatest(tick))
+	}
+	if err := ts.s.CommitBatch(batch); err != nil {
+		// Not much to do but report it; the data isn't lost, it just
+		// stays pending and will be retried on the next flush.
+		ts.mu.Lock()
+		ts.pending = append(pending, ts.pending...)
+		for symbol, tick := range latest {
+			if _, ok := ts.latest[symbol]; !ok {
+				ts.latest[symbol] = tick
+			}
+		}
+		ts.mu.Unlock()
+		log.Printf("tickstore: failed to flush: %v", err)
+	}
+}
+
+// Latest returns the most recently appended tick for symbol, including any
+// tick appended since the last flush.
+func (ts *Store) Latest(symbol string) (Tick, error) {
+	ts.mu.Lock()
+	if tick, ok := ts.latest[symbol]; ok {
+		ts.mu.Unlock()
+		return tick, nil
+	}
+	ts.mu.Unlock()
+
+	val, err := ts.s.Get(latestKey(symbol))
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return Tick{}, store.ErrKeyNotFound
+		}
+		return Tick{}, fmt.Errorf("tickstore: %w", err)
+	}
+	return decodeLatest(val)
+}
+
+// Iterator walks ticks in chronological order.
+type Iterator interface {
+	Next() bool
+	Tick() Tick
+}
+
+type sliceIterator struct {
+	ticks []Tick
+	pos   int
+}
+
+func (it *sliceIterator) Next() bool {
+	it.pos++
+	return it.pos < len(it.ticks)
+}
+
+func (it *sliceIterator) Tick() Tick {
+	return it.ticks[it.pos]
+}
+
+// Range returns every tick for symbol in [from, to), in chronological
+// order, including ticks appended since the last flush.
+func (ts *Store) Range(symbol string, from, to time.Time) (Iterator, error) {
+	var inMemory []Tick
+
+	ts.mu.Lock()
+	for _, pb := range ts.pending {
+		if pb.symbol == symbol {
+			inMemory = append(inMemory, pb.ticks...)
+		}
+	}
+	inMemory = append(inMemory, ts.open[symbol]...)
+	ts.mu.Unlock()
+
+	var ticks []Tick
+	it := ts.s.PrefixScan(symbolPrefix(symbol))
+	for it.Next() {
+		blk, err := decodeBlock(it.Value())
+		if err != nil {
+			it.Close()
+			return nil, fmt.Errorf("tickstore: corrupt block for %s: %w", symbol, err)
+		}
+		if len(blk) > 0 && blk[0].Time.After(to) {
+			break
+		}
+		for _, tick := range blk {
+			if !tick.Time.Before(from) && tick.Time.Before(to) {
+				ticks = append(ticks, tick)
+			}
+		}
+	}
+	if err := it.Close(); err != nil {
+		return nil, fmt.Errorf("tickstore: %w", err)
+	}
+
+	for _, tick := range inMemory {
+		if !tick.Time.Before(from) && tick.Time.Before(to) {
+			ticks = append(ticks, tick)
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Time.Before(ticks[j].Time) })
+
+	return &sliceIterator{ticks: ticks, pos: -1}, nil
+}
+
+// Close flushes any pending data and stops the background flusher.
+func (ts *Store) Close() error {
+	close(ts.stop)
+	ts.wg.Wait()
+	return nil
+}
@@ -1,19 +1,41 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/alpacahq/alpaca-trade-api-go/stream"
 
 	"github.com/alpacahq/alpaca-trade-api-go/alpaca"
 	"github.com/alpacahq/alpaca-trade-api-go/common"
 	"github.com/joho/godotenv"
+
+	"github.com/morgangallant/trading/journal"
+	"github.com/morgangallant/trading/store"
+	_ "github.com/morgangallant/trading/store/leveldb"
+	_ "github.com/morgangallant/trading/store/memory"
+	_ "github.com/morgangallant/trading/store/sql"
+	"github.com/morgangallant/trading/strategy"
+	_ "github.com/morgangallant/trading/strategy/noop"
+	"github.com/morgangallant/trading/tickstore"
 )
 
 var (
-	store = flag.String("store", "/tmp/tradingstore", "Path to on-disk datastore")
+	storeDSN   = flag.String("store", "leveldb:///tmp/tradingstore", "Store DSN, e.g. leveldb:///tmp/tradingstore, memory://, sqlite:///tmp/a.db, or postgres://...")
+	dataDir    = flag.String("data-dir", "/tmp/tradingstore", "Directory for local durable state that isn't part of the Store (currently just the order journal)")
+	configPath = flag.String("config", "config.yaml", "Path to the strategy runtime's YAML config file")
+	mode       = flag.String("mode", "paper", "Order execution mode: \"paper\" (fill against quotes, no real orders) or \"live\" (submit real orders via Alpaca)")
+)
+
+const (
+	journalSyncInterval   = 5 * time.Second
+	journalRotateInterval = 5 * time.Minute
 )
 
 func init() {
@@ -25,6 +47,12 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "dump" {
+		if err := runDump(os.Args[2:]); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		return
+	}
 	flag.Parse()
 	if err := run(); err != nil {
 		log.Fatalf("error: %v", err)
@@ -32,31 +60,183 @@ func main() {
 }
 
 func run() error {
-	_, err := NewPersistantStore(*store)
+	cfg, err := strategy.LoadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load strategy config: %w", err)
+	}
+
+	s, err := store.Open(*storeDSN)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	ts := tickstore.New(s)
+	defer ts.Close()
+
+	ob := newOrderBook()
+	journalPath := filepath.Join(*dataDir, "orders.journal")
+	if err := journal.Load(journalPath, ob.restore); err != nil {
+		return fmt.Errorf("failed to replay order journal: %w", err)
+	}
+	jrnl, err := journal.Open(journalPath, journalSyncInterval)
 	if err != nil {
-		return fmt.Errorf("failed to create store: %w", err)
+		return fmt.Errorf("failed to open order journal: %w", err)
+	}
+	defer jrnl.Close()
+	// Rewrite the replayed journal as a single gob stream before anything
+	// is appended to it, so the next Load can read every record.
+	if err := jrnl.Rotate(ob.liveSnapshot()); err != nil {
+		return fmt.Errorf("failed to rotate order journal: %w", err)
 	}
+	go rotateJournalPeriodically(jrnl, ob)
+
 	client := alpaca.NewClient(common.Credentials())
 	acct, err := client.GetAccount()
 	if err != nil {
 		return fmt.Errorf("failed to get alpaca account data: %w", err)
 	}
 	log.Println(*acct)
-	if err := stream.Register(alpaca.TradeUpdates, handleTradeUpdate); err != nil {
+
+	runner, err := buildRunner(cfg, s, ts, client)
+	if err != nil {
+		return fmt.Errorf("failed to build strategy runner: %w", err)
+	}
+
+	if err := stream.Register(alpaca.TradeUpdates, func(msg interface{}) {
+		handleTradeUpdate(jrnl, ob, runner, msg)
+	}); err != nil {
 		return fmt.Errorf("failed to register for trade updates: %w", err)
 	}
-	if err := stream.Register("Q.AAPL", handleQuote); err != nil {
-		return fmt.Errorf("failed to register for aapl updates: %w", err)
+	for _, symbol := range cfg.Symbols {
+		if err := stream.Register("Q."+symbol, func(msg interface{}) {
+			handleQuote(ts, runner, msg)
+		}); err != nil {
+			return fmt.Errorf("failed to register for %s updates: %w", symbol, err)
+		}
 	}
 	select {}
 }
 
-func handleTradeUpdate(msg interface{}) {
+// buildRunner loads every strategy named in cfg, each checkpointing its
+// own state under "strat/<name>/..." via the Store's snapshot/transaction
+// primitives, and wires them to an OrderRouter selected by *mode.
+func buildRunner(cfg *strategy.Config, s store.Store, ts *tickstore.Store, client *alpaca.Client) (*strategy.Runner, error) {
+	var strategies []strategy.Strategy
+	for _, sc := range cfg.Strategies {
+		strat, err := strategy.New(sc.Name, sc.Params, strategy.NewCheckpoint(s, sc.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load strategy %q: %w", sc.Name, err)
+		}
+		strategies = append(strategies, strat)
+	}
+
+	risk := strategy.NewRiskGate(cfg.Risk)
+	book := strategy.NewPaperBook(s, risk)
+	var router strategy.OrderRouter
+	switch *mode {
+	case "paper":
+		router = strategy.NewPaperRouter(ts, book)
+	case "live":
+		router = strategy.NewLiveRouter(client)
+	default:
+		return nil, fmt.Errorf("unknown -mode %q, want \"paper\" or \"live\"", *mode)
+	}
+
+	return strategy.NewRunner(strategies, router, risk, book), nil
+}
+
+// rotateJournalPeriodically keeps the order journal from growing without
+// bound by rewriting it down to just the still-live orders on a fixed
+// interval.
+func rotateJournalPeriodically(jrnl *journal.Journal, ob *orderBook) {
+	t := time.NewTicker(journalRotateInterval)
+	defer t.Stop()
+	for range t.C {
+		if err := jrnl.Rotate(ob.liveSnapshot()); err != nil {
+			log.Printf("failed to rotate order journal: %v", err)
+		}
+	}
+}
+
+// orderBook tracks the latest known state of every order that hasn't yet
+// reached a terminal status, reconstructed from the journal at startup and
+// kept up to date as trade updates stream in.
+type orderBook struct {
+	mu   sync.Mutex
+	live map[journal.OrderID]journal.Order
+}
+
+func newOrderBook() *orderBook {
+	return &orderBook{live: make(map[journal.OrderID]journal.Order)}
+}
+
+// restore is the add callback passed to journal.Load at startup.
+func (ob *orderBook) restore(o journal.Order) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if o.Terminal() {
+		delete(ob.live, o.ID)
+		return nil
+	}
+	ob.live[o.ID] = o
+	return nil
+}
+
+// update records the latest state for an order as trade updates stream
+// in, dropping it from the live set once it's Terminal.
+func (ob *orderBook) update(o journal.Order) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if o.Terminal() {
+		delete(ob.live, o.ID)
+		return
+	}
+	ob.live[o.ID] = o
+}
+
+func (ob *orderBook) liveSnapshot() map[journal.OrderID]journal.Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	snap := make(map[journal.OrderID]journal.Order, len(ob.live))
+	for id, o := range ob.live {
+		snap[id] = o
+	}
+	return snap
+}
+
+func handleTradeUpdate(jrnl *journal.Journal, ob *orderBook, runner *strategy.Runner, msg interface{}) {
 	update := msg.(alpaca.TradeUpdate)
 	log.Printf("%s event received for order %s.", update.Event, update.Order.ID)
+	o := orderFromTradeUpdate(update)
+	ob.update(o)
+	if err := jrnl.Insert(o); err != nil {
+		log.Printf("failed to journal order %s: %v", o.ID, err)
+	}
+	runner.OnTradeUpdate(context.Background(), update)
 }
 
-func handleQuote(msg interface{}) {
+func orderFromTradeUpdate(update alpaca.TradeUpdate) journal.Order {
+	var updatedAt time.Time
+	if update.Order.UpdatedAt != nil {
+		updatedAt = *update.Order.UpdatedAt
+	}
+	return journal.Order{
+		ID:            journal.OrderID(update.Order.ID),
+		ClientOrderID: update.Order.ClientOrderID,
+		Symbol:        update.Order.Symbol,
+		Side:          string(update.Order.Side),
+		Qty:           update.Order.Qty.String(),
+		FilledQty:     update.Order.FilledQty.String(),
+		Status:        update.Order.Status,
+		UpdatedAt:     updatedAt,
+	}
+}
+
+func handleQuote(ts *tickstore.Store, runner *strategy.Runner, msg interface{}) {
 	quote := msg.(alpaca.StreamQuote)
 	log.Println(quote.Symbol, quote.BidPrice, quote.BidSize, quote.AskPrice, quote.AskSize)
+	mid := (quote.BidPrice + quote.AskPrice) / 2
+	if err := ts.Append(quote.Symbol, tickstore.Tick{Time: time.Now(), Price: mid}); err != nil {
+		log.Printf("failed to append tick for %s: %v", quote.Symbol, err)
+	}
+	runner.OnQuote(context.Background(), quote)
 }